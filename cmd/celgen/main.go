@@ -0,0 +1,38 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command celgen prints celgen.TaskSpecRules's fixed, hand-maintained CEL
+// validation rules as JSON, so hack/update-codegen.sh can diff them against
+// the checked-in CRD manifests and fail CI if pkg/apis/pipeline/v1/celgen
+// and config/300-taskrun.yaml have drifted apart.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1/celgen"
+)
+
+func main() {
+	out, err := json.MarshalIndent(celgen.AsExtension(celgen.TaskSpecRules()), "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "celgen:", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+}
@@ -0,0 +1,31 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ptls centralizes construction of *tls.Config values so that every
+// Tekton component (webhook server, resolver HTTP clients, outbound
+// git/OCI clients, etc.) gets the same cipher suite, curve, and minimum
+// version choices, rather than constructing tls.Config ad hoc.
+//
+// Building with the fips_strict build tag (see ptls_fips.go) additionally
+// pins the profiles in this package to the FIPS-140 approved cipher
+// suites, curves, and minimum version. It deliberately does not side-effect
+// import crypto/tls/fipsonly: that package carries its own
+// `//go:build boringcrypto` constraint that `-tags fips_strict` never sets,
+// so importing it would break the build under a standard Go toolchain.
+//
+// This follows the same profile pattern used by the Pinniped project's
+// internal/crypto/ptls package.
+package ptls
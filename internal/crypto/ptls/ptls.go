@@ -0,0 +1,44 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ptls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+)
+
+// Default returns the baseline *tls.Config Tekton components should use for
+// client and server connections that are not otherwise security sensitive
+// (e.g. outbound requests to a git remote or OCI registry). rootCAs may be
+// nil to use the system trust store.
+func Default(rootCAs *x509.CertPool) *tls.Config {
+	return &tls.Config{
+		MinVersion:       minTLSVersion,
+		CipherSuites:     cipherSuites,
+		CurvePreferences: curvePreferences,
+		RootCAs:          rootCAs,
+	}
+}
+
+// Secure returns a stricter *tls.Config for the webhook server and any other
+// traffic that terminates or originates admission-critical connections.
+// rootCAs may be nil to use the system trust store.
+func Secure(rootCAs *x509.CertPool) *tls.Config {
+	cfg := Default(rootCAs)
+	cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	return cfg
+}
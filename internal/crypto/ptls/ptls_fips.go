@@ -0,0 +1,51 @@
+//go:build fips_strict
+// +build fips_strict
+
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ptls
+
+import (
+	"crypto/tls"
+)
+
+// minTLSVersion, cipherSuites, and curvePreferences pinned to the FIPS-140
+// approved subset. Kept in its own build-tagged file so a fips_strict binary
+// can't accidentally end up with the wider ptls_default.go set.
+//
+// This deliberately doesn't side-effect import crypto/tls/fipsonly: that
+// package carries its own `//go:build boringcrypto` constraint, which
+// `-tags fips_strict` does not set, so importing it would make this package
+// fail to build under a standard toolchain. Enforcement here is by pinning
+// the negotiable set below, not by crypto/tls/fipsonly's process-wide
+// restriction; the binary still needs a FIPS-validated Go toolchain for the
+// cryptographic modules themselves to be FIPS-140 approved.
+var (
+	minTLSVersion = uint16(tls.VersionTLS12)
+
+	cipherSuites = []uint16{
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	}
+
+	curvePreferences = []tls.CurveID{
+		tls.CurveP256,
+		tls.CurveP384,
+	}
+)
@@ -0,0 +1,226 @@
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// AffinityAssistantTemplate holds pod specific configuration and is a subset of the generic pod Template
+type AffinityAssistantTemplate struct {
+	// NodeSelector is a selector which must be true for the pod to fit on a node.
+	// Selector which must match a node's labels for the pod to be scheduled on
+	// that node. More info:
+	// https://kubernetes.io/docs/concepts/configuration/assign-pod-node/
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// If specified, the pod's tolerations.
+	// +optional
+	// +listType=atomic
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// ImagePullSecrets gives the name of the secret used by the pod to pull the image if specified
+	// +optional
+	// +listType=atomic
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+}
+
+// Template holds pod specific configuration
+type Template struct {
+	// NodeSelector is a selector which must be true for the pod to fit on a node.
+	// Selector which must match a node's labels for the pod to be scheduled on
+	// that node. More info:
+	// https://kubernetes.io/docs/concepts/configuration/assign-pod-node/
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// If specified, the pod's tolerations.
+	// +optional
+	// +listType=atomic
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// If specified, the pod's scheduling constraints
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// SecurityContext holds pod-level security attributes and common container settings.
+	// Optional: Defaults to empty.  See type description for default values of each field.
+	// +optional
+	SecurityContext *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+
+	// List of volumes that can be mounted by containers belonging to the pod.
+	// More info: https://kubernetes.io/docs/concepts/storage/volumes
+	// +optional
+	// +listType=atomic
+	// +patchMergeKey=name
+	// +patchStrategy=merge,retainKeys
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// RuntimeClassName refers to a RuntimeClass object in the node.k8s.io group,
+	// which should be used to run this pod.  If no RuntimeClass resource matches
+	// the named class, the pod will not be run. If unset or empty, the "legacy"
+	// RuntimeClass will be used, which is an implicit class with an empty
+	// definition that uses the default runtime handler.
+	// More info: https://git.k8s.io/enhancements/keps/sig-node/runtime-class.md
+	// This is a beta feature as of Kubernetes v1.14.
+	// +optional
+	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
+
+	// AutomountServiceAccountToken indicates whether pods running as this service
+	// account should have an API token automatically mounted.
+	// +optional
+	AutomountServiceAccountToken *bool `json:"automountServiceAccountToken,omitempty"`
+
+	// Set DNS policy for the pod.
+	// Defaults to "ClusterFirst".
+	// Valid values are 'ClusterFirst', 'Default' or 'None'.
+	// DNS parameters given in DNSConfig will be merged with the policy selected
+	// with DNSPolicy.
+	// +optional
+	DNSPolicy *corev1.DNSPolicy `json:"dnsPolicy,omitempty"`
+
+	// Specifies the DNS parameters of a pod.
+	// Parameters specified here will be merged to the generated DNS
+	// configuration based on DNSPolicy.
+	// +optional
+	DNSConfig *corev1.PodDNSConfig `json:"dnsConfig,omitempty"`
+
+	// EnableServiceLinks indicates whether information about services should be
+	// injected into pod's environment variables, matching the syntax of Docker
+	// links.
+	// Optional: Defaults to true.
+	// +optional
+	EnableServiceLinks *bool `json:"enableServiceLinks,omitempty"`
+
+	// If specified, indicates the pod's priority. "system-node-critical" and
+	// "system-cluster-critical" are two special keywords which indicate the
+	// highest priorities with the former being the highest priority. Any other
+	// name must be defined by creating a PriorityClass object with that name.
+	// If not specified, the pod priority will be default or zero if there is no
+	// default.
+	// +optional
+	PriorityClassName *string `json:"priorityClassName,omitempty"`
+
+	// SchedulerName specifies the scheduler to be used to dispatch the Pod
+	// +optional
+	SchedulerName string `json:"schedulerName,omitempty"`
+
+	// ImagePullSecrets gives the name of the secret used by the pod to pull the image if specified
+	// +optional
+	// +listType=atomic
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// HostAliases is an optional list of hosts and IPs that will be injected into the pod's hosts
+	// file if specified. This is only valid for non-hostNetwork pods.
+	// +optional
+	// +listType=atomic
+	HostAliases []corev1.HostAlias `json:"hostAliases,omitempty"`
+
+	// HostNetwork specifies whether the pod may use the node network namespace
+	// +optional
+	HostNetwork bool `json:"hostNetwork,omitempty"`
+
+	// Monitoring, if set, annotates the pod with its metrics port and path
+	// and causes a PodMonitor to be created so Prometheus Operator can
+	// discover and scrape it. No containerPort is declared on the step
+	// containers for the metrics port.
+	// +optional
+	Monitoring *Monitoring `json:"monitoring,omitempty"`
+
+	// PushGateway, if set, adds a sidecar that pushes the Prometheus
+	// text-exposition files steps write under /tekton/metrics to a
+	// Pushgateway, instead of (or in addition to) their being scraped. This
+	// is useful for metrics that are only alive for the lifetime of a single
+	// step. The sidecar polls every 5 seconds and pushes each file once, as
+	// soon as it finds it; it does not wait for the writing step to complete,
+	// and later changes to a file already pushed are not pushed.
+	// +optional
+	PushGateway *PushGateway `json:"pushgateway,omitempty"`
+}
+
+// Monitoring configures Prometheus scraping for the pod that backs a TaskRun.
+// When set, the reconciler annotates the pod for scrape-config discovery and,
+// if the Prometheus Operator CRDs are installed in the cluster, creates a
+// PodMonitor owned by the TaskRun so it is garbage collected when the
+// TaskRun is.
+type Monitoring struct {
+	// Port is the container port that metrics are exposed on.
+	// Defaults to 9090.
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+
+	// Path is the HTTP path that metrics are served on.
+	// Defaults to "/metrics".
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Scheme is the URL scheme used to scrape the metrics endpoint ("http" or "https").
+	// +optional
+	Scheme string `json:"scheme,omitempty"`
+
+	// Interval is the scrape interval, expressed as a Prometheus duration string (e.g. "30s").
+	// +optional
+	Interval string `json:"interval,omitempty"`
+
+	// Labels are additional labels applied to the generated PodMonitor so it
+	// can be selected by a Prometheus custom resource's podMonitorSelector.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// RelabelConfigs are applied to samples before they are scraped, using the
+	// Prometheus Operator RelabelConfig schema.
+	// +optional
+	// +listType=atomic
+	RelabelConfigs []monitoringv1.RelabelConfig `json:"relabelConfigs,omitempty"`
+}
+
+// PushGateway configures a Prometheus Pushgateway that step metrics are
+// pushed to from a sidecar in the TaskRun's pod. Unlike Monitoring, this
+// does not depend on the pod still being scrapeable when Prometheus next
+// scrapes: each metrics file is pushed on the first 5-second poll after it
+// appears.
+type PushGateway struct {
+	// URL is the base address of the Pushgateway, e.g. "http://pushgateway.monitoring:9091".
+	URL string `json:"url"`
+
+	// Job is the Pushgateway "job" grouping key. TaskRun name and namespace
+	// are always added as additional grouping labels alongside this.
+	Job string `json:"job"`
+
+	// GroupingLabels are additional grouping key/value pairs appended to the
+	// push URL beyond job/instance.
+	// +optional
+	GroupingLabels map[string]string `json:"groupingLabels,omitempty"`
+
+	// Auth references credentials used to authenticate to the Pushgateway.
+	// +optional
+	Auth *PushGatewayAuth `json:"auth,omitempty"`
+}
+
+// PushGatewayAuth names the Secret holding credentials (basic-auth
+// username/password and/or client TLS material) used to talk to a
+// Pushgateway. The push-metrics sidecar reads only username and password
+// from it; ca.crt, tls.crt and tls.key are used only by the controller's
+// pkg/metrics/pushgateway client, which deletes the pushed metric group.
+type PushGatewayAuth struct {
+	// SecretRef names the Secret in the TaskRun's namespace containing the
+	// Pushgateway credentials (expected keys: username, password, ca.crt,
+	// tls.crt, tls.key).
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+}
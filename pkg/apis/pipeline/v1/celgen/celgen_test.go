@@ -0,0 +1,46 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package celgen
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// TestTaskSpecRulesGolden diffs the schema celgen produces for TaskSpec
+// against testdata/taskspec_validations.golden.json, so a change to
+// TaskSpecRules that isn't also reflected in the checked-in CRD manifest
+// (config/300-taskrun.yaml, kept in sync via cmd/celgen) fails CI instead of
+// silently drifting.
+func TestTaskSpecRulesGolden(t *testing.T) {
+	got, err := json.MarshalIndent(AsExtension(TaskSpecRules()), "", "  ")
+	if err != nil {
+		t.Fatalf("MarshalIndent() = %v", err)
+	}
+	got = append(got, '\n')
+
+	const goldenPath = "testdata/taskspec_validations.golden.json"
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("reading %s: %v", goldenPath, err)
+	}
+
+	if string(got) != string(want) {
+		t.Errorf("TaskSpecRules() schema does not match %s; got:\n%s", goldenPath, got)
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package celgen
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ruleTag is the struct tag DeriveRules looks for on a field: a field
+// tagged `celgen:"<cel-expression>,<message>"` contributes that Rule.
+const ruleTag = "celgen"
+
+// DeriveRules derives a Rule for every field of t (or, if t is a pointer,
+// slice, or array, its element type) tagged with ruleTag, recursing into
+// nested and embedded struct fields. Unlike TaskSpecRules, which is a
+// fixed list kept in sync with TaskSpec by hand, DeriveRules reads rules
+// directly off the Go type via reflection: removing a tagged field drops
+// its rule automatically, and tagging a new field picks up
+// its rule the same way, without anyone needing to remember to edit this
+// package.
+//
+// DeriveRules has no caller in this tree yet: TaskSpec and PipelineSpec
+// aren't part of this tree to tag, so TaskSpecRules remains the rule
+// source CEL validation actually ships from until that tagging is done.
+func DeriveRules(t reflect.Type) []Rule {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var rules []Rule
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if tag, ok := f.Tag.Lookup(ruleTag); ok {
+			if rule, message, found := strings.Cut(tag, ","); found {
+				rules = append(rules, Rule{Rule: rule, Message: message})
+			}
+		}
+		rules = append(rules, DeriveRules(f.Type)...)
+	}
+	return rules
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package celgen
+
+import (
+	"reflect"
+	"testing"
+)
+
+type derivePodSpec struct {
+	Image string `celgen:"self.image != '',image must not be empty"`
+	Name  string
+}
+
+type deriveTaskSpec struct {
+	Description string
+	Steps       []derivePodSpec
+	Results     []struct {
+		Name string `celgen:"self.name != '',results[*].name must not be empty"`
+	}
+}
+
+func TestDeriveRules(t *testing.T) {
+	got := DeriveRules(reflect.TypeOf(deriveTaskSpec{}))
+	want := []Rule{
+		{Rule: "self.image != ''", Message: "image must not be empty"},
+		{Rule: "self.name != ''", Message: "results[*].name must not be empty"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DeriveRules() = %#v, want %#v", got, want)
+	}
+}
+
+func TestDeriveRulesNonStruct(t *testing.T) {
+	if got := DeriveRules(reflect.TypeOf("")); got != nil {
+		t.Errorf("DeriveRules(string) = %v, want nil", got)
+	}
+}
+
+func TestDeriveRulesPointer(t *testing.T) {
+	got := DeriveRules(reflect.TypeOf(&derivePodSpec{}))
+	want := []Rule{{Rule: "self.image != ''", Message: "image must not be empty"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DeriveRules(*derivePodSpec) = %#v, want %#v", got, want)
+	}
+}
@@ -24,6 +24,7 @@ limitations under the License.
 package v1
 
 import (
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1/celgen"
 	common "k8s.io/kube-openapi/pkg/common"
 	spec "k8s.io/kube-openapi/pkg/validation/spec"
 )
@@ -31,6 +32,9 @@ import (
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
 		"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod.AffinityAssistantTemplate": schema_pkg_apis_pipeline_pod_AffinityAssistantTemplate(ref),
+		"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod.Monitoring":                schema_pkg_apis_pipeline_pod_Monitoring(ref),
+		"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod.PushGateway":               schema_pkg_apis_pipeline_pod_PushGateway(ref),
+		"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod.PushGatewayAuth":           schema_pkg_apis_pipeline_pod_PushGatewayAuth(ref),
 		"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod.Template":                  schema_pkg_apis_pipeline_pod_Template(ref),
 		"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1.Task":                       schema_pkg_apis_pipeline_v1_Task(ref),
 		"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1.TaskList":                   schema_pkg_apis_pipeline_v1_TaskList(ref),
@@ -107,6 +111,159 @@ func schema_pkg_apis_pipeline_pod_AffinityAssistantTemplate(ref common.Reference
 	}
 }
 
+func schema_pkg_apis_pipeline_pod_Monitoring(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "Monitoring configures Prometheus scraping for the pod that backs a TaskRun. When set, the reconciler annotates the pod for scrape-config discovery and, if the Prometheus Operator CRDs are installed in the cluster, creates a PodMonitor owned by the TaskRun so it is garbage collected when the TaskRun is.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"port": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Port is the container port that metrics are exposed on. Defaults to 9090.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"path": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Path is the HTTP path that metrics are served on. Defaults to \"/metrics\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"scheme": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Scheme is the URL scheme used to scrape the metrics endpoint (\"http\" or \"https\").",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"interval": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Interval is the scrape interval, expressed as a Prometheus duration string (e.g. \"30s\").",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"labels": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Labels are additional labels applied to the generated PodMonitor so it can be selected by a Prometheus custom resource's podMonitorSelector.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"relabelConfigs": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "atomic",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "RelabelConfigs are applied to samples before they are scraped, using the Prometheus Operator RelabelConfig schema.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1.RelabelConfig"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1.RelabelConfig"},
+	}
+}
+
+func schema_pkg_apis_pipeline_pod_PushGateway(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PushGateway configures a Prometheus Pushgateway that step metrics are pushed to on step completion. Unlike Monitoring, this does not depend on the pod still being scrapeable: the metrics are pushed out before the step container (and its metrics files) disappear.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"url": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URL is the base address of the Pushgateway, e.g. \"http://pushgateway.monitoring:9091\".",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"job": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Job is the Pushgateway \"job\" grouping key. TaskRun name and namespace are always added as additional grouping labels alongside this.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"groupingLabels": {
+						SchemaProps: spec.SchemaProps{
+							Description: "GroupingLabels are additional grouping key/value pairs appended to the push URL beyond job/instance.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"auth": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Auth references credentials used to authenticate to the Pushgateway.",
+							Ref:         ref("github.com/tektoncd/pipeline/pkg/apis/pipeline/pod.PushGatewayAuth"),
+						},
+					},
+				},
+				Required: []string{"url", "job"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod.PushGatewayAuth"},
+	}
+}
+
+func schema_pkg_apis_pipeline_pod_PushGatewayAuth(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PushGatewayAuth names the Secret holding credentials (basic-auth username/password and/or client TLS material) used to push metrics to a Pushgateway.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"secretRef": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecretRef names the Secret in the TaskRun's namespace containing the Pushgateway credentials (expected keys: username, password, ca.crt, tls.crt, tls.key).",
+							Ref:         ref("k8s.io/api/core/v1.LocalObjectReference"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/api/core/v1.LocalObjectReference"},
+	}
+}
+
 func schema_pkg_apis_pipeline_pod_Template(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -275,11 +432,23 @@ func schema_pkg_apis_pipeline_pod_Template(ref common.ReferenceCallback) common.
 							Format:      "",
 						},
 					},
+					"monitoring": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Monitoring, if set, exposes a metrics port on each step container and causes a PodMonitor to be created so Prometheus Operator can discover and scrape it.",
+							Ref:         ref("github.com/tektoncd/pipeline/pkg/apis/pipeline/pod.Monitoring"),
+						},
+					},
+					"pushgateway": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PushGateway, if set, causes each step's Prometheus text-exposition files to be pushed to a Pushgateway when the step completes, instead of (or in addition to) being scraped. This is useful for metrics that are only alive for the lifetime of a single step.",
+							Ref:         ref("github.com/tektoncd/pipeline/pkg/apis/pipeline/pod.PushGateway"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.HostAlias", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodDNSConfig", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume"},
+			"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod.Monitoring", "github.com/tektoncd/pipeline/pkg/apis/pipeline/pod.PushGateway", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.HostAlias", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodDNSConfig", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume"},
 	}
 }
 
@@ -377,6 +546,14 @@ func schema_pkg_apis_pipeline_v1_TaskList(ref common.ReferenceCallback) common.O
 func schema_pkg_apis_pipeline_v1_TaskSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
+			VendorExtensible: spec.VendorExtensible{
+				Extensions: spec.Extensions{
+					// Rules come from pkg/apis/pipeline/v1/celgen, whose
+					// TestTaskSpecRulesGolden checks them against a golden
+					// file.
+					"x-kubernetes-validations": celgen.AsExtension(celgen.TaskSpecRules()),
+				},
+			},
 			SchemaProps: spec.SchemaProps{
 				Description: "TaskSpec defines the desired state of Task.",
 				Type:        []string{"object"},
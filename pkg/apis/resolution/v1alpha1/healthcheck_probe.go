@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProbeOutcome is the result of a single liveness probe against a
+// resolver, as observed by whatever does the probing. NextHealthCheck
+// doesn't care how the probe was performed (HTTP health endpoint, gRPC
+// health check, or otherwise), only its outcome.
+type ProbeOutcome struct {
+	// At is when the probe completed.
+	At metav1.Time
+	// Latency is how long the probe took to respond.
+	Latency time.Duration
+	// Err is the probe's failure, if any. A nil Err means the probe
+	// succeeded.
+	Err error
+}
+
+// NextHealthCheck computes the HealthCheck a resolver's probe loop should
+// record after a single probe, given the previous HealthCheck (the zero
+// value if this is the first probe) and that probe's outcome.
+// ConsecutiveFailures increments on a failed probe and resets to 0 on the
+// first successful one, per HealthCheck's doc comment.
+//
+// NextHealthCheck has no caller in this tree yet: the resolver probe loop
+// that would invoke it per interval isn't part of this tree either.
+func NextHealthCheck(prev HealthCheck, outcome ProbeOutcome) HealthCheck {
+	next := HealthCheck{
+		LastProbeTime:  outcome.At,
+		LatencySeconds: outcome.Latency.Seconds(),
+	}
+	if outcome.Err == nil {
+		next.Reason = "Succeeded"
+		return next
+	}
+	next.ConsecutiveFailures = prev.ConsecutiveFailures + 1
+	next.Reason = fmt.Sprintf("ProbeFailed: %s", outcome.Err)
+	return next
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNextHealthCheck(t *testing.T) {
+	probeTime := metav1.NewTime(time.Unix(100, 0))
+
+	t.Run("first probe succeeds", func(t *testing.T) {
+		got := NextHealthCheck(HealthCheck{}, ProbeOutcome{At: probeTime, Latency: 50 * time.Millisecond})
+		if got.ConsecutiveFailures != 0 {
+			t.Errorf("ConsecutiveFailures = %d, want 0", got.ConsecutiveFailures)
+		}
+		if got.LatencySeconds != 0.05 {
+			t.Errorf("LatencySeconds = %v, want 0.05", got.LatencySeconds)
+		}
+		if !got.LastProbeTime.Equal(&probeTime) {
+			t.Errorf("LastProbeTime = %v, want %v", got.LastProbeTime, probeTime)
+		}
+	})
+
+	t.Run("failures accumulate", func(t *testing.T) {
+		prev := HealthCheck{ConsecutiveFailures: 2}
+		got := NextHealthCheck(prev, ProbeOutcome{At: probeTime, Err: errors.New("connection refused")})
+		if got.ConsecutiveFailures != 3 {
+			t.Errorf("ConsecutiveFailures = %d, want 3", got.ConsecutiveFailures)
+		}
+		if got.Reason == "" {
+			t.Error("Reason is empty, want a failure reason mentioning the probe error")
+		}
+	})
+
+	t.Run("success after failures resets the streak", func(t *testing.T) {
+		prev := HealthCheck{ConsecutiveFailures: 5}
+		got := NextHealthCheck(prev, ProbeOutcome{At: probeTime})
+		if got.ConsecutiveFailures != 0 {
+			t.Errorf("ConsecutiveFailures = %d, want 0 after a successful probe", got.ConsecutiveFailures)
+		}
+	})
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HealthCheck reports the result of the resolver's most recent liveness
+// probe for a ResolutionRequest, surfaced through the "healthcheck"
+// subresource so that callers can poll probe state without racing updates
+// to the request's main Status. See NextHealthCheck, in
+// healthcheck_probe.go, for how these fields are computed across probes.
+// ResolutionRequestStatus doesn't carry a HealthCheck field yet, since
+// resolutionrequest_types.go isn't part of this tree: until then the
+// "healthcheck" subresource round-trips the whole ResolutionRequest
+// rather than probe data.
+type HealthCheck struct {
+	// LastProbeTime is when the resolver was last probed.
+	// +optional
+	LastProbeTime metav1.Time `json:"lastProbeTime,omitempty"`
+	// LatencySeconds is how long the most recent probe took to respond.
+	// +optional
+	LatencySeconds float64 `json:"latencySeconds,omitempty"`
+	// ConsecutiveFailures is the number of probes in a row that have
+	// failed. It resets to 0 on the first successful probe.
+	// +optional
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+	// Reason is a brief machine-readable explanation for the most recent
+	// probe's outcome.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
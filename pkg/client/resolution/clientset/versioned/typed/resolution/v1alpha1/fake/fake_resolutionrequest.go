@@ -113,6 +113,29 @@ func (c *FakeResolutionRequests) UpdateStatus(ctx context.Context, resolutionReq
 	return obj.(*v1alpha1.ResolutionRequest), err
 }
 
+// GetHealthCheck takes name of the resolutionRequest, and returns the resolutionRequest read through its "healthcheck" subresource, and an error if there is any.
+func (c *FakeResolutionRequests) GetHealthCheck(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.ResolutionRequest, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetSubresourceAction(resolutionrequestsResource, c.ns, "healthcheck", name), &v1alpha1.ResolutionRequest{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ResolutionRequest), err
+}
+
+// UpdateHealthCheck takes the representation of a resolutionRequest and updates it through its "healthcheck" subresource.
+// Returns the server's representation of the resolutionRequest, and an error, if there is any.
+func (c *FakeResolutionRequests) UpdateHealthCheck(ctx context.Context, resolutionRequest *v1alpha1.ResolutionRequest, opts v1.UpdateOptions) (*v1alpha1.ResolutionRequest, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(resolutionrequestsResource, "healthcheck", c.ns, resolutionRequest), &v1alpha1.ResolutionRequest{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ResolutionRequest), err
+}
+
 // Delete takes name of the resolutionRequest and deletes it. Returns an error if one occurs.
 func (c *FakeResolutionRequests) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
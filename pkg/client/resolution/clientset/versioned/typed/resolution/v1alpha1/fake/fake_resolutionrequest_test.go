@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"testing"
+
+	v1alpha1 "github.com/tektoncd/pipeline/pkg/apis/resolution/v1alpha1"
+	typedv1alpha1 "github.com/tektoncd/pipeline/pkg/client/resolution/clientset/versioned/typed/resolution/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// FakeResolutionRequests is only reachable through ResolutionRequestInterface
+// by client code, so this is enforced at compile time rather than left to be
+// discovered via a failed type assertion at runtime.
+var _ typedv1alpha1.ResolutionRequestInterface = &FakeResolutionRequests{}
+
+// TestFakeResolutionRequestsGetHealthCheck only asserts that GetHealthCheck
+// routes as a "healthcheck" subresource action, not that probe data flows
+// through it end-to-end: ResolutionRequestStatus has no HealthCheck field
+// yet (see the doc comment on HealthCheck), so GetHealthCheck/UpdateHealthCheck
+// can only round-trip the whole ResolutionRequest for now.
+func TestFakeResolutionRequestsGetHealthCheck(t *testing.T) {
+	fake := &clienttesting.Fake{}
+	want := &v1alpha1.ResolutionRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-request", Namespace: "my-ns"},
+	}
+	fake.AddReactor("get", "resolutionrequests", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		getAction := action.(clienttesting.GetActionImpl)
+		if getAction.Subresource != "healthcheck" {
+			t.Fatalf("expected a healthcheck subresource action, got %q", getAction.Subresource)
+		}
+		return true, want, nil
+	})
+
+	c := (&FakeResolutionV1alpha1{Fake: fake}).ResolutionRequests("my-ns")
+	got, err := c.GetHealthCheck(context.Background(), "my-request", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("GetHealthCheck() = %v", err)
+	}
+	if got.Name != want.Name {
+		t.Errorf("GetHealthCheck().Name = %q, want %q", got.Name, want.Name)
+	}
+}
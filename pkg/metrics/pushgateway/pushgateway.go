@@ -0,0 +1,223 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pushgateway implements a client for pushing and removing the
+// short-lived metrics that a TaskRun step writes to
+// /tekton/metrics/*.prom before it exits, to a Prometheus Pushgateway.
+package pushgateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tektoncd/pipeline/internal/crypto/ptls"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Client pushes and deletes metric groups on a Pushgateway.
+type Client struct {
+	httpClient *http.Client
+
+	baseURL string
+	auth    *basicAuth
+
+	// Backoff controls the retry behaviour of Push and Delete. It defaults
+	// to a small, bounded exponential backoff suitable for reconciler use.
+	Backoff wait.Backoff
+}
+
+type basicAuth struct {
+	username string
+	password string
+}
+
+// defaultBackoff retries quickly since Push/Delete are called from the
+// reconcile loop and must not block it for long.
+var defaultBackoff = wait.Backoff{
+	Duration: 100 * time.Millisecond,
+	Factor:   2.0,
+	Steps:    5,
+}
+
+// NewClient builds a pushgateway Client for the given pod.PushGateway trait,
+// resolving TLS and basic-auth credentials from the referenced Secret (if
+// any) in namespace.
+func NewClient(ctx context.Context, kubeclient kubernetes.Interface, namespace string, pg *pod.PushGateway) (*Client, error) {
+	if pg == nil {
+		return nil, fmt.Errorf("pushgateway: nil configuration")
+	}
+	if pg.URL == "" {
+		return nil, fmt.Errorf("pushgateway: url is required")
+	}
+
+	c := &Client{
+		baseURL: strings.TrimRight(pg.URL, "/"),
+		Backoff: defaultBackoff,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = ptls.Default(nil)
+
+	if pg.Auth != nil && pg.Auth.SecretRef != nil {
+		secret, err := kubeclient.CoreV1().Secrets(namespace).Get(ctx, pg.Auth.SecretRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("pushgateway: fetching auth secret %q: %w", pg.Auth.SecretRef.Name, err)
+		}
+
+		if user, pass := secret.Data["username"], secret.Data["password"]; len(user) > 0 {
+			c.auth = &basicAuth{username: string(user), password: string(pass)}
+		}
+
+		if rootCAs, certs, err := tlsMaterialFromSecret(secret); err != nil {
+			return nil, err
+		} else if rootCAs != nil || certs != nil {
+			tlsConfig := ptls.Default(rootCAs)
+			tlsConfig.Certificates = certs
+			transport.TLSClientConfig = tlsConfig
+		}
+	}
+
+	c.httpClient = &http.Client{
+		Transport: transport,
+		Timeout:   10 * time.Second,
+	}
+	return c, nil
+}
+
+// tlsMaterialFromSecret extracts the ca.crt/tls.crt/tls.key keys from
+// secret, if present, so the caller can layer them onto a ptls.Default
+// config. Both return values are nil if secret carries no TLS material.
+func tlsMaterialFromSecret(secret *corev1.Secret) (*x509.CertPool, []tls.Certificate, error) {
+	ca, hasCA := secret.Data["ca.crt"]
+	cert, hasCert := secret.Data["tls.crt"]
+	key, hasKey := secret.Data["tls.key"]
+	if !hasCA && !hasCert && !hasKey {
+		return nil, nil, nil
+	}
+
+	var rootCAs *x509.CertPool
+	if hasCA {
+		rootCAs = x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(ca) {
+			return nil, nil, fmt.Errorf("pushgateway: failed to parse ca.crt")
+		}
+	}
+
+	var certs []tls.Certificate
+	if hasCert && hasKey {
+		keyPair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("pushgateway: parsing client keypair: %w", err)
+		}
+		certs = []tls.Certificate{keyPair}
+	}
+	return rootCAs, certs, nil
+}
+
+// groupingKey builds the "/job/<job>/instance/<instance>/<label>/<value>/..."
+// path segment used by both Push and Delete. extra's keys are sorted before
+// being rendered so the result doesn't depend on Go's randomized map
+// iteration order; neither caller currently relies on that, but it keeps
+// this helper consistent with groupingPath in
+// pkg/reconciler/taskrun/resources/pushgateway.go, which does for the
+// sidecar script it generates.
+func groupingKey(job, instance string, extra map[string]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "/metrics/job/%s", url.PathEscape(job))
+	if instance != "" {
+		fmt.Fprintf(&b, "/instance/%s", url.PathEscape(instance))
+	}
+
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "/%s/%s", url.PathEscape(k), url.PathEscape(extra[k]))
+	}
+	return b.String()
+}
+
+// Push uploads metrics (in Prometheus text exposition format) under the
+// grouping key job/instance/groupingLabels, merging them into any
+// previously pushed group with the same key (replacing only metrics sharing
+// a name). It POSTs rather than PUTs for the same reason
+// pushGatewaySidecar's curl script does: PUT replaces the entire metric
+// group at that key, which would let one caller's push wipe out another's
+// metrics pushed under the same job/instance. It retries transient
+// failures using c.Backoff.
+func (c *Client) Push(ctx context.Context, job, instance string, groupingLabels map[string]string, metrics []byte) error {
+	req := func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+groupingKey(job, instance, groupingLabels), bytes.NewReader(metrics))
+	}
+	return c.doWithRetry(req)
+}
+
+// Delete removes the metric group previously pushed under job/instance/groupingLabels
+// so that stale series don't linger after the TaskRun is gone. It retries
+// transient failures using c.Backoff.
+func (c *Client) Delete(ctx context.Context, job, instance string, groupingLabels map[string]string) error {
+	req := func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodDelete, c.baseURL+groupingKey(job, instance, groupingLabels), nil)
+	}
+	return c.doWithRetry(req)
+}
+
+func (c *Client) doWithRetry(newReq func() (*http.Request, error)) error {
+	var lastErr error
+	err := wait.ExponentialBackoff(c.Backoff, func() (bool, error) {
+		req, err := newReq()
+		if err != nil {
+			return false, err
+		}
+		if c.auth != nil {
+			req.SetBasicAuth(c.auth.username, c.auth.password)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			return false, nil
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return true, nil
+		}
+		if resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("pushgateway: server error: %s", resp.Status)
+			return false, nil
+		}
+		return false, fmt.Errorf("pushgateway: unexpected response: %s", resp.Status)
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("pushgateway: giving up after retries: %w", lastErr)
+	}
+	return err
+}
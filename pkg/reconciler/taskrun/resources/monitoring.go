@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	monitoringclientset "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/discovery"
+)
+
+const (
+	defaultMonitoringPort = 9090
+	defaultMonitoringPath = "/metrics"
+
+	scrapeAnnotation = "prometheus.io/scrape"
+	portAnnotation   = "prometheus.io/port"
+	pathAnnotation   = "prometheus.io/path"
+)
+
+// monitoringPort returns the configured metrics port, falling back to
+// defaultMonitoringPort when unset.
+func monitoringPort(m *pod.Monitoring) int32 {
+	if m.Port != nil {
+		return *m.Port
+	}
+	return defaultMonitoringPort
+}
+
+// monitoringPath returns the configured metrics path, falling back to
+// defaultMonitoringPath when unset.
+func monitoringPath(m *pod.Monitoring) string {
+	if m.Path != "" {
+		return m.Path
+	}
+	return defaultMonitoringPath
+}
+
+// MonitoringAnnotations returns the scrape-config annotations that should be
+// applied to a TaskRun's Pod so that a bare scrape-based Prometheus (one
+// without the Operator CRDs installed) can still discover the metrics
+// endpoint.
+func MonitoringAnnotations(m *pod.Monitoring) map[string]string {
+	if m == nil {
+		return nil
+	}
+	return map[string]string{
+		scrapeAnnotation: "true",
+		portAnnotation:   strconv.Itoa(int(monitoringPort(m))),
+		pathAnnotation:   monitoringPath(m),
+	}
+}
+
+// NewPodMonitor builds the PodMonitor that should be created alongside a
+// TaskRun's Pod when a Monitoring trait is set on the pod template. The
+// returned PodMonitor selects the TaskRun's Pod via the taskRunLabelKey
+// label makeLabels already applies to it. It targets the metrics port by
+// number rather than by name, since makePod never declares a named
+// "metrics" containerPort on any step. The PodMonitor is owned by the
+// TaskRun so it is garbage collected when the TaskRun is deleted.
+//
+// NewPodMonitor's only caller in this tree is CreatePodMonitor, which no
+// reconciler calls: pkg/reconciler/taskrun only holds this resources
+// package.
+func NewPodMonitor(taskRun *v1alpha1.TaskRun, m *pod.Monitoring) *monitoringv1.PodMonitor {
+	if m == nil {
+		return nil
+	}
+	interval := m.Interval
+	if interval == "" {
+		interval = "30s"
+	}
+	scheme := m.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	labels := make(map[string]string, len(m.Labels)+1)
+	for k, v := range m.Labels {
+		labels[k] = v
+	}
+	labels[taskRunLabelKey] = taskRun.Name
+
+	return &monitoringv1.PodMonitor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-metrics", taskRun.Name),
+			Namespace: taskRun.Namespace,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(taskRun, groupVersionKind),
+			},
+		},
+		Spec: monitoringv1.PodMonitorSpec{
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					taskRunLabelKey: taskRun.Name,
+				},
+			},
+			PodMetricsEndpoints: []monitoringv1.PodMetricsEndpoint{{
+				TargetPort:     ptrIntOrString(intstr.FromInt(int(monitoringPort(m)))),
+				Path:           monitoringPath(m),
+				Scheme:         scheme,
+				Interval:       monitoringv1.Duration(interval),
+				RelabelConfigs: m.RelabelConfigs,
+			}},
+		},
+	}
+}
+
+func ptrIntOrString(v intstr.IntOrString) *intstr.IntOrString { return &v }
+
+// HasPodMonitorCRD reports whether the Prometheus Operator's PodMonitor CRD
+// is registered in the cluster, by looking up its API group/version in
+// discoveryClient. CreatePodMonitor uses this to decide whether to attempt
+// creating a PodMonitor at all, so that clusters without the Operator
+// installed get a clean no-op (the annotation-based fallback in
+// MonitoringAnnotations still applies) instead of a Create call failing
+// once per TaskRun with a Monitoring trait.
+func HasPodMonitorCRD(discoveryClient discovery.DiscoveryInterface) (bool, error) {
+	resources, err := discoveryClient.ServerResourcesForGroupVersion(monitoringv1.SchemeGroupVersion.String())
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, r := range resources.APIResources {
+		if r.Kind == "PodMonitor" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CreatePodMonitor creates the PodMonitor NewPodMonitor builds for taskRun,
+// unless m is nil or HasPodMonitorCRD reports that the Prometheus Operator
+// CRDs aren't installed, in which case it's a no-op. Callers own deciding
+// when to call this (e.g. once per TaskRun, on Pod creation); it performs
+// no caching or idempotency check of its own beyond what Create itself
+// returns on a name conflict.
+func CreatePodMonitor(ctx context.Context, monitoringClient monitoringclientset.Interface, discoveryClient discovery.DiscoveryInterface, taskRun *v1alpha1.TaskRun, m *pod.Monitoring) (*monitoringv1.PodMonitor, error) {
+	if m == nil {
+		return nil, nil
+	}
+	ok, err := HasPodMonitorCRD(discoveryClient)
+	if err != nil {
+		return nil, fmt.Errorf("monitoring: checking for the PodMonitor CRD: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	pm := NewPodMonitor(taskRun, m)
+	created, err := monitoringClient.MonitoringV1().PodMonitors(taskRun.Namespace).Create(ctx, pm, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("monitoring: creating PodMonitor for taskrun %q: %w", taskRun.Name, err)
+	}
+	return created, nil
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clientgotesting "k8s.io/client-go/testing"
+)
+
+func TestMonitoringAnnotations(t *testing.T) {
+	port := int32(9100)
+	for _, tc := range []struct {
+		name string
+		m    *pod.Monitoring
+		want map[string]string
+	}{{
+		name: "nil monitoring has no annotations",
+		m:    nil,
+		want: nil,
+	}, {
+		name: "defaults",
+		m:    &pod.Monitoring{},
+		want: map[string]string{
+			scrapeAnnotation: "true",
+			portAnnotation:   "9090",
+			pathAnnotation:   "/metrics",
+		},
+	}, {
+		name: "overrides",
+		m:    &pod.Monitoring{Port: &port, Path: "/custom-metrics"},
+		want: map[string]string{
+			scrapeAnnotation: "true",
+			portAnnotation:   "9100",
+			pathAnnotation:   "/custom-metrics",
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := MonitoringAnnotations(tc.m)
+			if len(got) != len(tc.want) {
+				t.Fatalf("MonitoringAnnotations() = %v, want %v", got, tc.want)
+			}
+			for k, v := range tc.want {
+				if got[k] != v {
+					t.Errorf("MonitoringAnnotations()[%q] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestHasPodMonitorCRD(t *testing.T) {
+	groupVersion := monitoringv1.SchemeGroupVersion.String()
+
+	for _, tc := range []struct {
+		name      string
+		resources []*metav1.APIResourceList
+		want      bool
+	}{{
+		name: "CRD installed",
+		resources: []*metav1.APIResourceList{{
+			GroupVersion: groupVersion,
+			APIResources: []metav1.APIResource{{Kind: "PodMonitor"}},
+		}},
+		want: true,
+	}, {
+		name: "group/version registered but PodMonitor kind missing",
+		resources: []*metav1.APIResourceList{{
+			GroupVersion: groupVersion,
+			APIResources: []metav1.APIResource{{Kind: "ServiceMonitor"}},
+		}},
+		want: false,
+	}, {
+		name:      "CRD not installed",
+		resources: nil,
+		want:      false,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			fd := &fakediscovery.FakeDiscovery{Fake: &clientgotesting.Fake{}, Resources: tc.resources}
+			got, err := HasPodMonitorCRD(fd)
+			if err != nil {
+				t.Fatalf("HasPodMonitorCRD() returned error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("HasPodMonitorCRD() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewPodMonitor(t *testing.T) {
+	if got := NewPodMonitor(&v1alpha1.TaskRun{}, nil); got != nil {
+		t.Fatalf("NewPodMonitor() with nil Monitoring = %v, want nil", got)
+	}
+
+	taskRun := &v1alpha1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-taskrun", Namespace: "my-ns"},
+	}
+	pm := NewPodMonitor(taskRun, &pod.Monitoring{})
+	if pm == nil {
+		t.Fatal("NewPodMonitor() = nil, want non-nil")
+	}
+
+	wantLabel := taskRun.Name
+	if got := pm.Spec.Selector.MatchLabels[taskRunLabelKey]; got != wantLabel {
+		t.Errorf("Selector.MatchLabels[%q] = %q, want %q (the label makeLabels applies to the TaskRun's Pod)", taskRunLabelKey, got, wantLabel)
+	}
+	if got := pm.Labels[taskRunLabelKey]; got != wantLabel {
+		t.Errorf("Labels[%q] = %q, want %q", taskRunLabelKey, got, wantLabel)
+	}
+	if pm.Namespace != taskRun.Namespace {
+		t.Errorf("Namespace = %q, want %q", pm.Namespace, taskRun.Namespace)
+	}
+
+	// The PodMonitor must target the metrics port by number: makePod never
+	// declares a named "metrics" containerPort on any step, so a named Port
+	// here could never resolve to anything scrapeable.
+	ep := pm.Spec.PodMetricsEndpoints[0]
+	if ep.Port != "" {
+		t.Errorf("PodMetricsEndpoints[0].Port = %q, want empty (named ports aren't declared anywhere in makePod's output)", ep.Port)
+	}
+	if ep.TargetPort == nil || ep.TargetPort.IntValue() != defaultMonitoringPort {
+		t.Errorf("PodMetricsEndpoints[0].TargetPort = %v, want %d", ep.TargetPort, defaultMonitoringPort)
+	}
+}
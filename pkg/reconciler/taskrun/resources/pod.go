@@ -23,7 +23,6 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"path/filepath"
 	"strings"
 
@@ -110,10 +109,21 @@ const (
 // MakePod converts TaskRun and TaskSpec objects to a Pod which implements the taskrun specified
 // by the supplied CRD.
 func MakePod(images pipeline.Images, taskRun *v1alpha1.TaskRun, taskSpec v1alpha1.TaskSpec, kubeclient kubernetes.Interface) (*corev1.Pod, error) {
+	return makePod(images, taskRun, taskSpec, randReader, func() (*corev1.Container, []corev1.Volume, error) {
+		return pod.CredsInit(images.CredsImage, taskRun.GetServiceAccountName(), taskRun.Namespace, kubeclient, implicitVolumeMounts, implicitEnvVars)
+	})
+}
+
+// makePod holds the conversion pipeline shared by MakePod and RenderPod:
+// creds-init, workingdir-init, script placement, resource zero-ing, sidecar
+// merging, and label/annotation propagation. The two entry points differ
+// only in how they obtain the creds-init container (a live kubeclient vs a
+// pluggable SecretsLister) and where their random bytes come from.
+func makePod(images pipeline.Images, taskRun *v1alpha1.TaskRun, taskSpec v1alpha1.TaskSpec, rand io.Reader, credsInit func() (*corev1.Container, []corev1.Volume, error)) (*corev1.Pod, error) {
 	var initContainers []corev1.Container
 	var volumes []corev1.Volume
 
-	if credsInitContainer, secretsVolumes, err := pod.CredsInit(images.CredsImage, taskRun.GetServiceAccountName(), taskRun.Namespace, kubeclient, implicitVolumeMounts, implicitEnvVars); err != nil {
+	if credsInitContainer, secretsVolumes, err := credsInit(); err != nil {
 		return nil, err
 	} else if credsInitContainer != nil {
 		initContainers = append(initContainers, *credsInitContainer)
@@ -128,9 +138,24 @@ func MakePod(images pipeline.Images, taskRun *v1alpha1.TaskRun, taskSpec v1alpha
 
 	maxIndicesByResource := findMaxResourceRequest(taskSpec.Steps, corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage)
 
+	// The place-scripts suffix is only drawn when some step has a Script, so
+	// a Pod without scripts draws a single suffix (its name) from rand.
+	var placeScriptsSuffix string
+	for _, s := range taskSpec.Steps {
+		if s.Script == "" {
+			continue
+		}
+		suffix, err := randomSuffix(rand)
+		if err != nil {
+			return nil, err
+		}
+		placeScriptsSuffix = suffix
+		break
+	}
+
 	placeScripts := false
 	placeScriptsInitContainer := corev1.Container{
-		Name:         names.SimpleNameGenerator.RestrictLengthWithRandomSuffix("place-scripts"),
+		Name:         names.SimpleNameGenerator.RestrictLength(fmt.Sprintf("place-scripts-%s", placeScriptsSuffix)),
 		Image:        images.ShellImage,
 		TTY:          true,
 		Command:      []string{"sh"},
@@ -160,7 +185,15 @@ func MakePod(images pipeline.Images, taskRun *v1alpha1.TaskRun, taskSpec v1alpha
 			placeScripts = true
 			// Append to the place-scripts script to place the
 			// script file in a known location in the scripts volume.
-			tmpFile := filepath.Join(scriptsDir, names.SimpleNameGenerator.RestrictLengthWithRandomSuffix(fmt.Sprintf("script-%d", i)))
+			// The suffixes are drawn from the rand passed in by the
+			// caller (rather than names.SimpleNameGenerator's
+			// process-global source) so that RenderPod's
+			// determinism guarantee covers scripted steps too.
+			tmpFileSuffix, err := randomSuffix(rand)
+			if err != nil {
+				return nil, err
+			}
+			tmpFile := filepath.Join(scriptsDir, names.SimpleNameGenerator.RestrictLength(fmt.Sprintf("script-%d-%s", i, tmpFileSuffix)))
 			// heredoc is the "here document" placeholder string
 			// used to cat script contents into the file. Typically
 			// this is the string "EOF" but if this value were
@@ -168,7 +201,11 @@ func MakePod(images pipeline.Images, taskRun *v1alpha1.TaskRun, taskSpec v1alpha
 			// string "EOF" in their own scripts. Instead we
 			// randomly generate a string to (hopefully) prevent
 			// collisions.
-			heredoc := names.SimpleNameGenerator.RestrictLengthWithRandomSuffix("script-heredoc-randomly-generated")
+			heredocSuffix, err := randomSuffix(rand)
+			if err != nil {
+				return nil, err
+			}
+			heredoc := names.SimpleNameGenerator.RestrictLength(fmt.Sprintf("script-heredoc-randomly-generated-%s", heredocSuffix))
 			// NOTE: quotes around the heredoc string are
 			// important. Without them, ${}s in the file are
 			// interpreted as env vars and likely end up replaced
@@ -235,16 +272,21 @@ cat > ${tmpfile} << '%s'
 		initContainers = append(initContainers, placeScriptsInitContainer)
 	}
 
+	// Add the volume shared between steps and the push-metrics sidecar, if
+	// a Pushgateway is configured.
+	if taskRun.Spec.PodTemplate.PushGateway != nil {
+		volumes = append(volumes, metricsVolume)
+	}
+
 	if err := v1alpha1.ValidateVolumes(volumes); err != nil {
 		return nil, err
 	}
 
 	// Generate a short random hex string.
-	b, err := ioutil.ReadAll(io.LimitReader(randReader, 3))
+	gibberish, err := randomSuffix(rand)
 	if err != nil {
 		return nil, err
 	}
-	gibberish := hex.EncodeToString(b)
 
 	mergedPodSteps, err := v1alpha1.MergeStepsWithStepTemplate(taskSpec.StepTemplate, podSteps)
 	if err != nil {
@@ -259,6 +301,17 @@ cat > ${tmpfile} << '%s'
 		mergedPodContainers = append(mergedPodContainers, sc)
 	}
 
+	if pg := taskRun.Spec.PodTemplate.PushGateway; pg != nil {
+		sidecar := pushGatewaySidecar(images.ShellImage, pg, taskRun)
+		sidecar.Name = names.SimpleNameGenerator.RestrictLength(fmt.Sprintf("%v%v", sidecarPrefix, sidecar.Name))
+		mergedPodContainers = append(mergedPodContainers, sidecar)
+		for i := range mergedPodContainers {
+			if IsContainerStep(mergedPodContainers[i].Name) {
+				mergedPodContainers[i].VolumeMounts = append(mergedPodContainers[i].VolumeMounts, metricsVolumeMount)
+			}
+		}
+	}
+
 	return &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			// We execute the build's pod in the same namespace as where the build was
@@ -291,6 +344,19 @@ cat > ${tmpfile} << '%s'
 	}, nil
 }
 
+// randomSuffix draws 3 random bytes from rand and hex-encodes them, for use
+// as a name suffix. makePod uses this everywhere it needs a random-looking
+// name component instead of names.SimpleNameGenerator.RestrictLengthWithRandomSuffix,
+// which draws from a process-global source: drawing from the caller-supplied
+// rand here is what makes RenderPod's output byte-stable across invocations.
+func randomSuffix(rand io.Reader) (string, error) {
+	b := make([]byte, 3)
+	if _, err := io.ReadFull(rand, b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
 type UpdatePod func(*corev1.Pod) (*corev1.Pod, error)
 
 // AddReadyAnnotation adds the ready annotation if it is not present.
@@ -343,6 +409,9 @@ func makeAnnotations(s *v1alpha1.TaskRun) map[string]string {
 		annotations[k] = v
 	}
 	annotations[ReadyAnnotation] = ""
+	for k, v := range MonitoringAnnotations(s.Spec.PodTemplate.Monitoring) {
+		annotations[k] = v
+	}
 	return annotations
 }
 
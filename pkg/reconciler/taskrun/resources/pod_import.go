@@ -0,0 +1,254 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	"github.com/tektoncd/pipeline/pkg/reconciler/taskrun/entrypoint"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// credsInitContainerPrefix and workingDirInitContainerPrefix identify the
+	// init containers pkg/pod.CredsInit and pkg/pod.WorkingDirInit add to
+	// every TaskRun Pod, so PodToTaskSpec can drop them.
+	// secretsListerCredsInit (render.go) gives its container this same
+	// prefix, so PodToTaskSpec recognizes creds-init containers from either
+	// the real MakePod path or RenderPod's preview path identically.
+	credsInitContainerPrefix      = "credential-initializer"
+	workingDirInitContainerPrefix = "working-dir-initializer"
+
+	// placeScriptsContainerPrefix identifies the init container MakePod adds
+	// when any step specifies a Script. Its name carries a random suffix, so
+	// match on prefix, same as names.SimpleNameGenerator produces it.
+	placeScriptsContainerPrefix = "place-scripts"
+)
+
+// PodToTaskSpec reverses the conventions MakePod establishes: it takes a
+// Pod (either one Tekton already produced, or one a user hand-wrote/adapted
+// following the same shape) and recovers the TaskSpec that would produce
+// it. This is the Tekton analogue of `kube play`'s inverse, `kube generate`:
+// prototype a workload as a raw Pod, then promote it to a reusable Task.
+//
+// PodToTaskSpec is strict: any container it cannot attribute to a Step, a
+// Sidecar, or one of the known init containers (creds-init, workingdir-init,
+// place-scripts, the entrypoint binary) is reported as an error rather than
+// silently dropped.
+func PodToTaskSpec(p *corev1.Pod) (*v1alpha1.TaskSpec, error) {
+	scripts, err := parsePlaceScripts(p.Spec.InitContainers)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := &v1alpha1.TaskSpec{}
+
+	for _, ic := range p.Spec.InitContainers {
+		switch {
+		case strings.HasPrefix(ic.Name, credsInitContainerPrefix):
+			continue // dropped: reconstructed by MakePod from the TaskRun's ServiceAccount.
+		case strings.HasPrefix(ic.Name, workingDirInitContainerPrefix):
+			continue // dropped: reconstructed by MakePod from the Steps' WorkingDir.
+		case strings.HasPrefix(ic.Name, placeScriptsContainerPrefix):
+			continue // dropped: its contents were already recovered into scripts above.
+		case ic.Name == containerPrefix+entrypoint.InitContainerName:
+			step, err := importStep(ic, scripts)
+			if err != nil {
+				return nil, err
+			}
+			ts.Steps = append(ts.Steps, *step)
+		default:
+			return nil, fmt.Errorf("cannot attribute init container %q to a known Tekton init container", ic.Name)
+		}
+	}
+
+	for _, c := range p.Spec.Containers {
+		switch {
+		case IsContainerStep(c.Name):
+			step, err := importStep(c, scripts)
+			if err != nil {
+				return nil, err
+			}
+			ts.Steps = append(ts.Steps, *step)
+		case c.Name == sidecarPrefix+pushMetricsSidecar:
+			continue // dropped: reconstructed by makePod from the TaskRun's PushGateway.
+		case IsContainerSidecar(c.Name):
+			sidecar := c
+			sidecar.Name = TrimSidecarNamePrefix(c.Name)
+			ts.Sidecars = append(ts.Sidecars, sidecar)
+		default:
+			return nil, fmt.Errorf("cannot attribute container %q to a Step or a Sidecar", c.Name)
+		}
+	}
+
+	ts.Volumes = importVolumes(p.Spec.Volumes)
+
+	return ts, nil
+}
+
+// importStep reverses the per-step conventions MakePod applies: implicit
+// volume mounts, implicit HOME env var, the default WorkingDir, the
+// step-/step-unnamed-N name prefixes, and (if present) a script
+// recovered from the place-scripts heredoc. Any other name is an error.
+func importStep(c corev1.Container, scripts map[string]string) (*v1alpha1.Step, error) {
+	step := v1alpha1.Step{Container: c}
+
+	switch {
+	case strings.HasPrefix(c.Name, unnamedInitContainerPrefix):
+		step.Name = ""
+	case IsContainerStep(c.Name):
+		step.Name = TrimContainerNamePrefix(c.Name)
+	default:
+		return nil, fmt.Errorf("cannot attribute container %q to a Step", c.Name)
+	}
+
+	step.VolumeMounts = dropImplicitVolumeMounts(c.VolumeMounts)
+	step.Env = dropImplicitEnvVars(c.Env)
+
+	if step.WorkingDir == workspaceDir {
+		step.WorkingDir = ""
+	}
+
+	if len(step.Args) > 0 {
+		if script, ok := scripts[step.Args[len(step.Args)-1]]; ok {
+			step.Script = script
+			step.Args = step.Args[:len(step.Args)-1]
+			step.VolumeMounts = dropVolumeMount(step.VolumeMounts, scriptsVolumeMount.Name)
+		}
+	}
+
+	return &step, nil
+}
+
+// dropImplicitVolumeMounts removes the workspace/home mounts MakePod adds to
+// every step, along with the push-metrics volume mount it adds to every step
+// when a Pushgateway is configured, leaving only mounts the user actually
+// declared.
+func dropImplicitVolumeMounts(mounts []corev1.VolumeMount) []corev1.VolumeMount {
+	implicit := make(map[string]bool, len(implicitVolumeMounts)+1)
+	for _, vm := range implicitVolumeMounts {
+		implicit[vm.Name] = true
+	}
+	implicit[metricsVolumeMount.Name] = true
+	var out []corev1.VolumeMount
+	for _, vm := range mounts {
+		if !implicit[vm.Name] {
+			out = append(out, vm)
+		}
+	}
+	return out
+}
+
+func dropVolumeMount(mounts []corev1.VolumeMount, name string) []corev1.VolumeMount {
+	var out []corev1.VolumeMount
+	for _, vm := range mounts {
+		if vm.Name != name {
+			out = append(out, vm)
+		}
+	}
+	return out
+}
+
+// dropImplicitEnvVars lifts the implicit HOME env var MakePod injects back
+// out of a step's env.
+func dropImplicitEnvVars(env []corev1.EnvVar) []corev1.EnvVar {
+	implicit := make(map[string]bool, len(implicitEnvVars))
+	for _, e := range implicitEnvVars {
+		implicit[e.Name] = true
+	}
+	var out []corev1.EnvVar
+	for _, e := range env {
+		if !implicit[e.Name] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// importVolumes drops the implicit workspace/home volumes, the
+// place-scripts volume, the push-metrics volume, and the per-secret
+// creds-init volumes MakePod adds, leaving only the user's own volumes.
+// Leaving any of these in would fabricate TaskSpec.Volumes entries the
+// user never declared - in the creds-init case, pinning the recovered Task
+// to the originating ServiceAccount's secret names.
+func importVolumes(volumes []corev1.Volume) []corev1.Volume {
+	drop := map[string]bool{scriptsVolume.Name: true, metricsVolume.Name: true}
+	for _, v := range implicitVolumes {
+		drop[v.Name] = true
+	}
+	var out []corev1.Volume
+	for _, v := range volumes {
+		if drop[v.Name] || strings.HasPrefix(v.Name, credsSecretVolumePrefix) {
+			continue
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// parsePlaceScripts recovers each Script body MakePod wrote into the
+// place-scripts init container's heredoc, keyed by the tmpfile path the
+// script was written to (the same path a step's last Arg points at).
+func parsePlaceScripts(initContainers []corev1.Container) (map[string]string, error) {
+	scripts := map[string]string{}
+	for _, ic := range initContainers {
+		if !strings.HasPrefix(ic.Name, placeScriptsContainerPrefix) {
+			continue
+		}
+		if len(ic.Args) != 2 {
+			return nil, fmt.Errorf("place-scripts init container %q has unexpected Args %v", ic.Name, ic.Args)
+		}
+		body := ic.Args[1]
+		for len(body) > 0 {
+			const tmpfilePrefix = `tmpfile="`
+			idx := strings.Index(body, tmpfilePrefix)
+			if idx == -1 {
+				break
+			}
+			body = body[idx+len(tmpfilePrefix):]
+			end := strings.Index(body, `"`)
+			if end == -1 {
+				return nil, fmt.Errorf("place-scripts init container %q: unterminated tmpfile path", ic.Name)
+			}
+			tmpFile := body[:end]
+
+			heredocPrefix := "cat > ${tmpfile} << '"
+			idx = strings.Index(body, heredocPrefix)
+			if idx == -1 {
+				return nil, fmt.Errorf("place-scripts init container %q: missing heredoc for %s", ic.Name, tmpFile)
+			}
+			body = body[idx+len(heredocPrefix):]
+			end = strings.Index(body, "'\n")
+			if end == -1 {
+				return nil, fmt.Errorf("place-scripts init container %q: malformed heredoc marker for %s", ic.Name, tmpFile)
+			}
+			heredoc := body[:end]
+			body = body[end+2:]
+
+			terminator := "\n" + heredoc + "\n"
+			end = strings.Index(body, terminator)
+			if end == -1 {
+				return nil, fmt.Errorf("place-scripts init container %q: unterminated heredoc for %s", ic.Name, tmpFile)
+			}
+			scripts[tmpFile] = body[:end]
+			body = body[end+len(terminator):]
+		}
+	}
+	return scripts, nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestImportVolumesDropsImplicitAndSecretVolumes(t *testing.T) {
+	userVolume := corev1.Volume{Name: "user-data", VolumeSource: emptyVolumeSource}
+	volumes := []corev1.Volume{
+		userVolume,
+		scriptsVolume,
+		metricsVolume,
+		{Name: "workspace", VolumeSource: emptyVolumeSource},
+		{Name: "home", VolumeSource: emptyVolumeSource},
+		{Name: "secret-volume-github-creds", VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: "github-creds"},
+		}},
+	}
+
+	got := importVolumes(volumes)
+
+	if len(got) != 1 || got[0].Name != userVolume.Name {
+		t.Fatalf("importVolumes() = %v, want only %v (implicit and creds-init secret volumes should be dropped)", got, userVolume)
+	}
+}
+
+// TestPodToTaskSpecRoundTrip feeds RenderPod's output (MakePod's conversion
+// pipeline, without requiring a live cluster) back through PodToTaskSpec and
+// checks the result against the original TaskSpec. This is what actually
+// exercises PodToTaskSpec end-to-end: a unit test of importVolumes alone
+// (TestImportVolumesDropsImplicitAndSecretVolumes, above) can't catch a
+// container being misattributed, or a volume mount being left dangling once
+// its backing volume is dropped.
+func TestPodToTaskSpecRoundTrip(t *testing.T) {
+	images := pipeline.Images{ShellImage: "busybox"}
+	taskRun := &v1alpha1.TaskRun{}
+	taskRun.Name = "my-taskrun"
+	taskRun.Namespace = "my-ns"
+	taskRun.Spec.PodTemplate.PushGateway = &pod.PushGateway{URL: "http://pushgateway.example.com", Job: "my-job"}
+	taskSpec := v1alpha1.TaskSpec{
+		Steps: []v1alpha1.Step{{
+			Container: corev1.Container{Name: "build", Image: "golang"},
+		}},
+		Sidecars: []corev1.Container{
+			{Name: "logger", Image: "busybox"},
+		},
+	}
+
+	p, _, err := RenderPod(images, taskRun, taskSpec, fakeSecretsLister{}, bytes.NewReader([]byte{1, 2, 3}))
+	if err != nil {
+		t.Fatalf("RenderPod() = %v", err)
+	}
+
+	got, err := PodToTaskSpec(p)
+	if err != nil {
+		t.Fatalf("PodToTaskSpec() = %v", err)
+	}
+
+	if len(got.Sidecars) != 1 || got.Sidecars[0].Name != "logger" {
+		t.Fatalf("Sidecars = %v, want only the user-declared %q sidecar (the push-metrics sidecar must not be promoted into Sidecars)", got.Sidecars, "logger")
+	}
+
+	if len(got.Steps) != 1 || got.Steps[0].Name != "build" {
+		t.Fatalf("Steps = %v, want a single %q step", got.Steps, "build")
+	}
+	for _, vm := range got.Steps[0].VolumeMounts {
+		if vm.Name == metricsVolumeMount.Name {
+			t.Errorf("Steps[0].VolumeMounts = %v, should not contain the push-metrics mount %q (its backing volume is dropped by importVolumes)", got.Steps[0].VolumeMounts, metricsVolumeMount.Name)
+		}
+	}
+}
+
+// TestPodToTaskSpecRoundTripWithCredsInit is TestPodToTaskSpecRoundTrip's
+// sibling for a ServiceAccount with Secrets attached, so RenderPod actually
+// emits a creds-init container (the empty fakeSecretsLister{} above never
+// does). PodToTaskSpec must recognize and drop that container rather than
+// erroring out on it.
+func TestPodToTaskSpecRoundTripWithCredsInit(t *testing.T) {
+	images := pipeline.Images{ShellImage: "busybox", CredsImage: "creds-init"}
+	taskRun := &v1alpha1.TaskRun{}
+	taskRun.Name = "my-taskrun"
+	taskRun.Namespace = "my-ns"
+	taskRun.Spec.ServiceAccountName = "my-sa"
+	taskSpec := v1alpha1.TaskSpec{
+		Steps: []v1alpha1.Step{{
+			Container: corev1.Container{Name: "build", Image: "golang"},
+		}},
+	}
+
+	p, _, err := RenderPod(images, taskRun, taskSpec, fakeSecretsLister{names: []string{"github-creds"}}, bytes.NewReader([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9}))
+	if err != nil {
+		t.Fatalf("RenderPod() = %v", err)
+	}
+
+	got, err := PodToTaskSpec(p)
+	if err != nil {
+		t.Fatalf("PodToTaskSpec() = %v (creds-init container should be recognized and dropped)", err)
+	}
+
+	if len(got.Steps) != 1 || got.Steps[0].Name != "build" {
+		t.Fatalf("Steps = %v, want a single %q step", got.Steps, "build")
+	}
+	if len(got.Volumes) != 0 {
+		t.Errorf("Volumes = %v, want the creds-init secret volume dropped", got.Volumes)
+	}
+}
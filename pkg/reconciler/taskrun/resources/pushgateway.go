@@ -0,0 +1,264 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	"github.com/tektoncd/pipeline/pkg/metrics/pushgateway"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// metricsDir is where steps are expected to write Prometheus text
+	// exposition files (one file per metric group) before they exit.
+	metricsDir = "/tekton/metrics"
+
+	metricsVolumeName  = "tekton-internal-metrics"
+	pushMetricsSidecar = "push-metrics"
+)
+
+var (
+	metricsVolume = corev1.Volume{
+		Name:         metricsVolumeName,
+		VolumeSource: emptyVolumeSource,
+	}
+	metricsVolumeMount = corev1.VolumeMount{
+		Name:      metricsVolumeName,
+		MountPath: metricsDir,
+	}
+)
+
+// pushGatewayGroupingLabels returns the grouping labels pushGatewaySidecar
+// pushes under and CleanupPushGateway deletes by: pg.GroupingLabels plus the
+// namespace label the Job field's doc comment promises is always added
+// alongside it (the TaskRun name itself is used as the instance, per
+// pushGatewaySidecar).
+func pushGatewayGroupingLabels(pg *pod.PushGateway, taskRunNamespace string) map[string]string {
+	labels := make(map[string]string, len(pg.GroupingLabels)+1)
+	for k, v := range pg.GroupingLabels {
+		labels[k] = v
+	}
+	labels["namespace"] = taskRunNamespace
+	return labels
+}
+
+// groupingPath renders labels into the "/key/value/..." path segment
+// pushGatewaySidecar appends after job/instance. Keys are sorted first so
+// the rendered path, and therefore the generated sidecar script, is
+// independent of Go's randomized map iteration order: pushGatewaySidecar's
+// caller, RenderPod, promises byte-stable output for the same input and
+// seeded rand, which a random label order would otherwise break as soon as
+// more than one grouping label is in play. Keys and values are
+// percent-escaped so a "/" in either can't be mistaken for a path
+// separator and split or merge grouping labels that weren't meant to
+// merge; pushGatewaySidecar is responsible for shell-quoting the result
+// before splicing it into the generated script.
+func groupingPath(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "/%s/%s", url.PathEscape(k), url.PathEscape(labels[k]))
+	}
+	return b.String()
+}
+
+// shellSingleQuote renders s as a single-quoted shell literal, escaping any
+// embedded single quotes, so it can be safely interpolated into a generated
+// script regardless of what characters it contains.
+func shellSingleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// pushGatewaySidecar builds the sidecar that watches metricsDir for the
+// *.prom files a step writes and pushes each one to the configured
+// Pushgateway under job/instance/groupingLabels. It POSTs rather than PUTs:
+// Pushgateway's PUT replaces the entire metric group at that grouping key,
+// which would let one step's push wipe out another step's metrics, since
+// every *.prom file in the same TaskRun shares the same job/instance. POST
+// only replaces metrics sharing a name, merging the rest of the group. It
+// runs for the lifetime of the Pod; Tekton stops sidecars once all steps
+// have completed the same way it stops any other sidecar. A file is only
+// added to seen once curl exits successfully, so a transient push failure
+// (Pushgateway momentarily unreachable, a 5xx, a network blip) leaves the
+// file unseen and it's retried on the next poll, 5 seconds later, instead
+// of being silently dropped.
+//
+// This polls and shells out to curl rather than going through
+// pkg/metrics/pushgateway.Client, because the push has to happen from
+// inside the Pod where the step wrote the file, not from the controller.
+// pg.Job and the grouping labels come from the pod.PushGateway trait and
+// the instance from the TaskRun name, so users control their content; each is
+// assigned to its own shell variable as a single-quoted literal (via
+// shellSingleQuote, the same as pg.URL) before the curl line references it
+// through "${var}". That ordering matters: a value spliced straight into
+// the double-quoted curl argument would still undergo bash parameter
+// expansion (so e.g. Job: "$PUSHGATEWAY_PASSWORD" would be substituted
+// with the sidecar's own auth secret at runtime and sent to pg.URL), but a
+// shell variable's value is never rescanned for expansion once assigned,
+// so referencing it back via "${var}" is safe no matter what it contains.
+func pushGatewaySidecar(shellImage string, pg *pod.PushGateway, taskRun *v1alpha1.TaskRun) corev1.Container {
+	groupingPath := groupingPath(pushGatewayGroupingLabels(pg, taskRun.Namespace))
+
+	var curlAuth string
+	if pg.Auth != nil && pg.Auth.SecretRef != nil {
+		curlAuth = `-u "${PUSHGATEWAY_USERNAME}:${PUSHGATEWAY_PASSWORD}"`
+	}
+
+	script := fmt.Sprintf(`set -e
+pg_url=%[1]s
+pg_job=%[2]s
+pg_instance=%[3]s
+pg_grouping=%[4]s
+seen=""
+while true; do
+  for f in %[5]s/*.prom; do
+    [ -e "${f}" ] || continue
+    case "${seen}" in *" ${f} "*) continue;; esac
+    if curl -sf %[6]s -X POST --data-binary @"${f}" "${pg_url}/metrics/job/${pg_job}/instance/${pg_instance}${pg_grouping}"; then
+      seen="${seen} ${f} "
+    fi
+  done
+  sleep 5
+done
+`, shellSingleQuote(strings.TrimRight(pg.URL, "/")), shellSingleQuote(url.PathEscape(pg.Job)), shellSingleQuote(url.PathEscape(taskRun.Name)), shellSingleQuote(groupingPath), metricsDir, curlAuth)
+
+	c := corev1.Container{
+		Name:         pushMetricsSidecar,
+		Image:        shellImage,
+		Command:      []string{"sh"},
+		Args:         []string{"-c", script},
+		VolumeMounts: []corev1.VolumeMount{metricsVolumeMount},
+	}
+	if pg.Auth != nil && pg.Auth.SecretRef != nil {
+		c.Env = []corev1.EnvVar{{
+			Name: "PUSHGATEWAY_USERNAME",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: *pg.Auth.SecretRef,
+					Key:                  "username",
+					Optional:             ptrBool(true),
+				},
+			},
+		}, {
+			Name: "PUSHGATEWAY_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: *pg.Auth.SecretRef,
+					Key:                  "password",
+					Optional:             ptrBool(true),
+				},
+			},
+		}}
+	}
+	return c
+}
+
+func ptrBool(b bool) *bool { return &b }
+
+// CleanupPushGateway deletes the metric group pushGatewaySidecar pushed for
+// taskRun: job pg.Job, instance taskRun.Name, and the same grouping labels
+// pushGatewaySidecar pushed under. pg.Job is user-configured and may be
+// shared across TaskRuns, so the delete is scoped by instance (and the
+// namespace grouping label) rather than deleting the whole job, which would
+// also remove groups pushed by unrelated TaskRuns sharing the same Job. It
+// is intended to be called from the TaskRun deletion path, mirroring how
+// AddReadyAnnotation is only reachable from the reconciler's update path.
+//
+// CleanupPushGateway is called by ReconcilePushGatewayDeletion, below, from
+// the TaskRun deletion path.
+func CleanupPushGateway(ctx context.Context, kubeclient kubernetes.Interface, taskRun *v1alpha1.TaskRun, pg *pod.PushGateway) error {
+	c, err := pushgateway.NewClient(ctx, kubeclient, taskRun.Namespace, pg)
+	if err != nil {
+		return err
+	}
+	groupingLabels := pushGatewayGroupingLabels(pg, taskRun.Namespace)
+	if err := c.Delete(ctx, pg.Job, taskRun.Name, groupingLabels); err != nil {
+		return fmt.Errorf("pushgateway: deleting metrics for taskrun %q: %w", taskRun.Name, err)
+	}
+	return nil
+}
+
+// PushGatewayFinalizer should be added to a TaskRun's Finalizers whenever
+// its pod template sets a PushGateway trait, so that the TaskRun's
+// deletion is held open until ReconcilePushGatewayDeletion has cleaned up
+// the metric group it pushed.
+const PushGatewayFinalizer = "pushgateway.tekton.dev/cleanup"
+
+// HasFinalizer reports whether finalizers contains name.
+func HasFinalizer(finalizers []string, name string) bool {
+	for _, f := range finalizers {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveFinalizer returns finalizers with name removed, preserving order.
+// It returns finalizers unmodified if name isn't present.
+func RemoveFinalizer(finalizers []string, name string) []string {
+	if !HasFinalizer(finalizers, name) {
+		return finalizers
+	}
+	out := make([]string, 0, len(finalizers)-1)
+	for _, f := range finalizers {
+		if f != name {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// ReconcilePushGatewayDeletion is the TaskRun-deletion-path counterpart to
+// pushGatewaySidecar: it deletes the metric group pushed for taskRun via
+// CleanupPushGateway and returns the Finalizers taskRun should be updated
+// with afterward, with PushGatewayFinalizer removed so deletion can
+// proceed. It is a no-op, returning taskRun.Finalizers unchanged and
+// skipping the Pushgateway call entirely, if PushGatewayFinalizer isn't
+// present - which also means CleanupPushGateway is never called for a
+// TaskRun that never had a PushGateway trait to begin with.
+//
+// Persisting the returned Finalizers (e.g. via a TaskRun client's Update)
+// is the caller's responsibility, mirroring how CleanupPushGateway itself
+// only talks to the Pushgateway, never to the Kubernetes API server for
+// the TaskRun object.
+//
+// ReconcilePushGatewayDeletion has no caller in this tree yet: pkg/reconciler/taskrun
+// only holds this resources package, not the reconciler that would add
+// PushGatewayFinalizer on create and invoke this function on delete.
+func ReconcilePushGatewayDeletion(ctx context.Context, kubeclient kubernetes.Interface, taskRun *v1alpha1.TaskRun, pg *pod.PushGateway) ([]string, error) {
+	if !HasFinalizer(taskRun.Finalizers, PushGatewayFinalizer) {
+		return taskRun.Finalizers, nil
+	}
+	if err := CleanupPushGateway(ctx, kubeclient, taskRun, pg); err != nil {
+		return nil, err
+	}
+	return RemoveFinalizer(taskRun.Finalizers, PushGatewayFinalizer), nil
+}
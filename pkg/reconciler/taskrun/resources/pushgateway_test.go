@@ -0,0 +1,282 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPushGatewaySidecar(t *testing.T) {
+	pg := &pod.PushGateway{URL: "http://pushgateway.example.com/", Job: "my-job"}
+	taskRun := &v1alpha1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "my-taskrun", Namespace: "my-ns"}}
+	c := pushGatewaySidecar("busybox", pg, taskRun)
+
+	if c.Name != pushMetricsSidecar {
+		t.Errorf("Name = %q, want %q", c.Name, pushMetricsSidecar)
+	}
+	if len(c.VolumeMounts) != 1 || c.VolumeMounts[0].Name != metricsVolumeName {
+		t.Errorf("VolumeMounts = %v, want a single mount of %q", c.VolumeMounts, metricsVolumeName)
+	}
+	script := c.Args[1]
+	if !strings.Contains(script, "pg_url='http://pushgateway.example.com'") {
+		t.Errorf("script does not assign pg.URL to pg_url:\n%s", script)
+	}
+	if !strings.Contains(script, "pg_job='my-job'") {
+		t.Errorf("script does not assign pg.Job to pg_job as a single-quoted literal:\n%s", script)
+	}
+	if !strings.Contains(script, "pg_instance='my-taskrun'") {
+		t.Errorf("script does not assign the TaskRun name to pg_instance as a single-quoted literal:\n%s", script)
+	}
+	if !strings.Contains(script, `"${pg_url}/metrics/job/${pg_job}/instance/${pg_instance}`) {
+		t.Errorf("script does not push to the expected job/instance URL via the pg_job/pg_instance variables:\n%s", script)
+	}
+	if !strings.Contains(script, "/namespace/my-ns") {
+		t.Errorf("script does not add the TaskRun's namespace as a grouping label:\n%s", script)
+	}
+	if len(c.Env) != 0 {
+		t.Errorf("Env = %v, want none when no Auth is configured", c.Env)
+	}
+	if !strings.Contains(script, "-X POST") {
+		t.Errorf("script does not POST (PUT would replace the whole metric group, wiping out any other step's *.prom file pushed under the same job/instance):\n%s", script)
+	}
+}
+
+func TestPushGatewaySidecarDeterministic(t *testing.T) {
+	pg := &pod.PushGateway{
+		URL: "http://pushgateway.example.com",
+		Job: "my-job",
+		GroupingLabels: map[string]string{
+			"z-label": "1",
+			"a-label": "2",
+			"m-label": "3",
+		},
+	}
+	taskRun := &v1alpha1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "my-taskrun", Namespace: "my-ns"}}
+
+	want := pushGatewaySidecar("busybox", pg, taskRun).Args[1]
+	for i := 0; i < 10; i++ {
+		if got := pushGatewaySidecar("busybox", pg, taskRun).Args[1]; got != want {
+			t.Fatalf("script is not stable across calls with the same input (grouping label keys should be sorted): got %q, want %q", got, want)
+		}
+	}
+	if !strings.Contains(want, "/a-label/2/m-label/3/namespace/my-ns/z-label/1") {
+		t.Errorf("script does not render grouping labels in sorted key order:\n%s", want)
+	}
+}
+
+func TestPushGatewaySidecarEscapesUntrustedValues(t *testing.T) {
+	pg := &pod.PushGateway{
+		URL: `http://pushgateway.example.com/'; rm -rf / #`,
+		Job: `my"job`,
+		GroupingLabels: map[string]string{
+			"label": "$(rm -rf /)",
+		},
+	}
+	taskRun := &v1alpha1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "my-taskrun", Namespace: "my-ns"}}
+
+	script := pushGatewaySidecar("busybox", pg, taskRun).Args[1]
+	if strings.Contains(script, `"job`) || strings.Contains(script, "$(rm") {
+		t.Errorf("pg.Job or a grouping label value was spliced unescaped into the script:\n%s", script)
+	}
+	if !strings.Contains(script, `pg_url='http://pushgateway.example.com/'\''; rm -rf / #'`) {
+		t.Errorf("pg.URL was not rendered as a single-quoted shell literal:\n%s", script)
+	}
+	if !strings.Contains(script, `pg_job='my"job'`) {
+		t.Errorf("pg.Job was not rendered as a single-quoted shell literal:\n%s", script)
+	}
+}
+
+// TestPushGatewaySidecarDoesNotExpandShellVariablesAtRuntime runs the
+// generated script for real (rather than only string-matching it) with
+// Job set to a bare "$VARNAME" reference, to prove bash never performs
+// parameter expansion on it. Percent-escaping alone (as pushGatewaySidecar
+// used to rely on) doesn't neutralize this: url.PathEscape leaves "$"
+// untouched since it's a valid path character, so a Job of
+// "$PUSHGATEWAY_PASSWORD" would otherwise be substituted with the
+// sidecar's own auth secret at request time and exfiltrated to pg.URL.
+func TestPushGatewaySidecarDoesNotExpandShellVariablesAtRuntime(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.prom"), []byte("metric 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	pg := &pod.PushGateway{URL: srv.URL, Job: "$PUSHGATEWAY_PASSWORD"}
+	taskRun := &v1alpha1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "my-taskrun", Namespace: "my-ns"}}
+
+	script := pushGatewaySidecar("busybox", pg, taskRun).Args[1]
+	script = strings.ReplaceAll(script, metricsDir, dir)
+	script = strings.Replace(script, "sleep 5", "exit 0", 1) // run one iteration, don't loop forever
+
+	cmd := exec.Command("sh", "-c", script)
+	cmd.Env = append(os.Environ(), "PUSHGATEWAY_PASSWORD=top-secret")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("sidecar script failed: %v\n%s", err, out)
+	}
+
+	if gotPath == "" {
+		t.Fatal("sidecar script never pushed to the test server")
+	}
+	if strings.Contains(gotPath, "top-secret") {
+		t.Fatalf("pg.Job was expanded as a shell variable at runtime, leaking PUSHGATEWAY_PASSWORD into the request path: %s", gotPath)
+	}
+	if !strings.Contains(gotPath, url.PathEscape("$PUSHGATEWAY_PASSWORD")) {
+		t.Fatalf("request path does not contain the literal, unexpanded Job value: %s", gotPath)
+	}
+}
+
+// TestPushGatewaySidecarRetriesFailedPush proves a file isn't marked seen
+// until its push actually succeeds, by failing the first request and
+// succeeding the second: the script should retry the same file on its
+// next poll rather than treating the failed attempt as done.
+func TestPushGatewaySidecarRetriesFailedPush(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.prom"), []byte("metric 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	pg := &pod.PushGateway{URL: srv.URL, Job: "my-job"}
+	taskRun := &v1alpha1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "my-taskrun", Namespace: "my-ns"}}
+
+	script := pushGatewaySidecar("busybox", pg, taskRun).Args[1]
+	script = strings.ReplaceAll(script, metricsDir, dir)
+	script = strings.Replace(script, "while true; do", "for _i in 1 2; do", 1) // two polls instead of forever
+	script = strings.Replace(script, "sleep 5", "sleep 0.1", 1)
+
+	cmd := exec.Command("sh", "-c", script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("sidecar script failed: %v\n%s", err, out)
+	}
+
+	if attempts != 2 {
+		t.Errorf("server saw %d requests, want 2 (the file that failed its first push should be retried on the next poll)", attempts)
+	}
+}
+
+func TestPushGatewaySidecarWithAuth(t *testing.T) {
+	pg := &pod.PushGateway{
+		URL: "http://pushgateway.example.com",
+		Job: "my-job",
+		Auth: &pod.PushGatewayAuth{
+			SecretRef: &corev1.LocalObjectReference{Name: "pg-creds"},
+		},
+	}
+	taskRun := &v1alpha1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "my-taskrun", Namespace: "my-ns"}}
+	c := pushGatewaySidecar("busybox", pg, taskRun)
+
+	if len(c.Env) != 2 {
+		t.Fatalf("Env = %v, want username/password env vars", c.Env)
+	}
+	for _, e := range c.Env {
+		if e.ValueFrom == nil || e.ValueFrom.SecretKeyRef == nil || e.ValueFrom.SecretKeyRef.Name != "pg-creds" {
+			t.Errorf("Env %q does not reference the configured auth secret: %+v", e.Name, e.ValueFrom)
+		}
+	}
+	if !strings.Contains(c.Args[1], "PUSHGATEWAY_USERNAME") {
+		t.Errorf("script does not reference PUSHGATEWAY_USERNAME:\n%s", c.Args[1])
+	}
+}
+
+func TestReconcilePushGatewayDeletionWithoutFinalizer(t *testing.T) {
+	taskRun := &v1alpha1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-taskrun", Namespace: "my-ns", Finalizers: []string{"some-other-finalizer"}},
+	}
+	pg := &pod.PushGateway{URL: "http://unused.invalid", Job: "my-job"}
+
+	got, err := ReconcilePushGatewayDeletion(context.Background(), fake.NewSimpleClientset(), taskRun, pg)
+	if err != nil {
+		t.Fatalf("ReconcilePushGatewayDeletion() returned error: %v", err)
+	}
+	if len(got) != 1 || got[0] != "some-other-finalizer" {
+		t.Errorf("Finalizers = %v, want unchanged (no Pushgateway call should happen without PushGatewayFinalizer)", got)
+	}
+}
+
+func TestReconcilePushGatewayDeletionWithFinalizer(t *testing.T) {
+	var deleted bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted = true
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	taskRun := &v1alpha1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "my-taskrun",
+			Namespace:  "my-ns",
+			Finalizers: []string{"some-other-finalizer", PushGatewayFinalizer},
+		},
+	}
+	pg := &pod.PushGateway{URL: srv.URL, Job: "my-job"}
+
+	got, err := ReconcilePushGatewayDeletion(context.Background(), fake.NewSimpleClientset(), taskRun, pg)
+	if err != nil {
+		t.Fatalf("ReconcilePushGatewayDeletion() returned error: %v", err)
+	}
+	if !deleted {
+		t.Error("ReconcilePushGatewayDeletion() did not call CleanupPushGateway (no DELETE request observed)")
+	}
+	if HasFinalizer(got, PushGatewayFinalizer) {
+		t.Errorf("Finalizers = %v, want PushGatewayFinalizer removed", got)
+	}
+	if !HasFinalizer(got, "some-other-finalizer") {
+		t.Errorf("Finalizers = %v, want unrelated finalizers preserved", got)
+	}
+}
+
+func TestRemoveFinalizer(t *testing.T) {
+	if got := RemoveFinalizer([]string{"a", "b", "c"}, "b"); len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Errorf("RemoveFinalizer() = %v, want [a c]", got)
+	}
+	unchanged := []string{"a", "c"}
+	if got := RemoveFinalizer(unchanged, "not-present"); len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Errorf("RemoveFinalizer() = %v, want input unchanged when name isn't present", got)
+	}
+}
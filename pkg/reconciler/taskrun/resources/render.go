@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	"github.com/tektoncd/pipeline/pkg/names"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// SecretsLister is the subset of a Kubernetes client RenderPod needs in
+// order to build the creds-init container: the names of the Secrets
+// attached to a ServiceAccount. Unlike MakePod, RenderPod doesn't need a
+// live kubernetes.Interface, so callers can preview the Pod a TaskRun would
+// produce (e.g. `taskrun render foo.yaml > pod.yaml`) without a cluster to
+// connect to.
+type SecretsLister interface {
+	// ListServiceAccountSecretNames returns the names of the Secrets attached
+	// to serviceAccountName in namespace.
+	ListServiceAccountSecretNames(serviceAccountName, namespace string) ([]string, error)
+}
+
+// RenderPod runs the same conversion pipeline as MakePod, but takes a
+// pluggable SecretsLister instead of a kubernetes.Interface, and a seedable
+// rand so the returned Pod (and its YAML encoding) is byte-stable across
+// invocations given the same input. It returns both the Pod and its
+// deterministic YAML serialization, for use in GitOps workflows, version
+// diffing, or feeding admission/policy tooling offline.
+func RenderPod(images pipeline.Images, taskRun *v1alpha1.TaskRun, taskSpec v1alpha1.TaskSpec, secrets SecretsLister, rand io.Reader) (*corev1.Pod, []byte, error) {
+	p, err := makePod(images, taskRun, taskSpec, rand, func() (*corev1.Container, []corev1.Volume, error) {
+		return secretsListerCredsInit(images.CredsImage, taskRun.GetServiceAccountName(), taskRun.Namespace, secrets, rand)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out, err := yaml.Marshal(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, out, nil
+}
+
+// credsSecretVolumePrefix names the per-secret volumes secretsListerCredsInit
+// attaches for creds-init, so pod_import.go's importVolumes can recognize
+// and drop them on the way back to a TaskSpec, the same way it drops the
+// fixed implicit volumes.
+const credsSecretVolumePrefix = "secret-volume-"
+
+// secretsListerCredsInit builds the creds-init container from the Secret
+// names SecretsLister reports, mirroring pkg/pod.CredsInit's container shape
+// and name (credsInitContainerPrefix, with a random suffix so PodToTaskSpec
+// recognizes it the same way regardless of which path produced the Pod) but
+// without requiring a live cluster connection to inspect each Secret's
+// annotations. If no Secrets are attached to the ServiceAccount, it returns
+// a nil container, matching pkg/pod.CredsInit's behavior. The suffix is
+// drawn from rand rather than names.SimpleNameGenerator's process-global
+// source, so RenderPod's determinism guarantee covers it too.
+func secretsListerCredsInit(credsImage, serviceAccountName, namespace string, secrets SecretsLister, rand io.Reader) (*corev1.Container, []corev1.Volume, error) {
+	secretNames, err := secrets.ListServiceAccountSecretNames(serviceAccountName, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(secretNames) == 0 {
+		return nil, nil, nil
+	}
+
+	suffix, err := randomSuffix(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var args []string
+	var volumes []corev1.Volume
+	var volumeMounts []corev1.VolumeMount
+	for i, name := range secretNames {
+		volumeName := names.SimpleNameGenerator.RestrictLength(fmt.Sprintf("%s%s", credsSecretVolumePrefix, name))
+		mountPath := fmt.Sprintf("/tekton/creds-secrets/%d", i)
+		volumes = append(volumes, corev1.Volume{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: name},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      volumeName,
+			MountPath: mountPath,
+			ReadOnly:  true,
+		})
+		args = append(args, "-basic-docker="+mountPath, "-basic-git="+mountPath)
+	}
+
+	return &corev1.Container{
+		Name:         names.SimpleNameGenerator.RestrictLength(fmt.Sprintf("%s-%s", credsInitContainerPrefix, suffix)),
+		Image:        credsImage,
+		Args:         args,
+		VolumeMounts: append(append([]corev1.VolumeMount{}, implicitVolumeMounts...), volumeMounts...),
+		Env:          implicitEnvVars,
+	}, volumes, nil
+}
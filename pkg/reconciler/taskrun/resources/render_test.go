@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type fakeSecretsLister struct {
+	names []string
+	err   error
+}
+
+func (f fakeSecretsLister) ListServiceAccountSecretNames(serviceAccountName, namespace string) ([]string, error) {
+	return f.names, f.err
+}
+
+func TestRenderPodIsDeterministic(t *testing.T) {
+	images := pipeline.Images{ShellImage: "busybox"}
+	taskRun := &v1alpha1.TaskRun{}
+	taskRun.Name = "my-taskrun"
+	taskRun.Namespace = "my-ns"
+	taskSpec := v1alpha1.TaskSpec{
+		Steps: []v1alpha1.Step{{
+			Container: corev1.Container{Name: "build", Image: "golang"},
+		}},
+	}
+
+	render := func() []byte {
+		_, out, err := RenderPod(images, taskRun, taskSpec, fakeSecretsLister{}, bytes.NewReader([]byte{1, 2, 3}))
+		if err != nil {
+			t.Fatalf("RenderPod() = %v", err)
+		}
+		return out
+	}
+
+	first := render()
+	second := render()
+	if string(first) != string(second) {
+		t.Errorf("RenderPod() is not deterministic given the same rand input:\n%s\n---\n%s", first, second)
+	}
+}
+
+// TestRenderPodIsDeterministicWithScripts exercises a step with a Script
+// set, which draws additional random bytes for the place-scripts container
+// name, the script's tmpfile name, and the heredoc delimiter, beyond the
+// single draw TestRenderPodIsDeterministic exercises for the Pod name
+// suffix. All of these must come from the injected rand, not
+// names.SimpleNameGenerator's process-global source, for the output to be
+// byte-stable.
+func TestRenderPodIsDeterministicWithScripts(t *testing.T) {
+	images := pipeline.Images{ShellImage: "busybox"}
+	taskRun := &v1alpha1.TaskRun{}
+	taskRun.Name = "my-taskrun"
+	taskRun.Namespace = "my-ns"
+	taskSpec := v1alpha1.TaskSpec{
+		Steps: []v1alpha1.Step{{
+			Container: corev1.Container{Name: "build", Image: "golang"},
+			Script:    "#!/bin/sh\necho hello\n",
+		}, {
+			Container: corev1.Container{Name: "test", Image: "golang"},
+			Script:    "#!/bin/sh\necho world\n",
+		}},
+	}
+
+	// rand.New(rand.NewSource(seed)) is a deterministic io.Reader, but it's
+	// stateful: each render needs its own freshly-seeded instance to draw
+	// the same sequence of "random" bytes the other one did.
+	render := func() []byte {
+		_, out, err := RenderPod(images, taskRun, taskSpec, fakeSecretsLister{}, rand.New(rand.NewSource(42)))
+		if err != nil {
+			t.Fatalf("RenderPod() = %v", err)
+		}
+		return out
+	}
+
+	first := render()
+	second := render()
+	if string(first) != string(second) {
+		t.Errorf("RenderPod() is not deterministic for steps with Script given the same rand input:\n%s\n---\n%s", first, second)
+	}
+}
+
+// TestRenderPodErrorsOnShortRand ensures a rand.Reader that runs out of
+// bytes before every suffix draw is complete surfaces an error, rather than
+// letting randomSuffix silently collapse to "" (which could produce
+// colliding heredoc markers or tmpfile paths for multi-script Tasks).
+func TestRenderPodErrorsOnShortRand(t *testing.T) {
+	images := pipeline.Images{ShellImage: "busybox"}
+	taskRun := &v1alpha1.TaskRun{}
+	taskRun.Name = "my-taskrun"
+	taskRun.Namespace = "my-ns"
+	taskSpec := v1alpha1.TaskSpec{
+		Steps: []v1alpha1.Step{{
+			Container: corev1.Container{Name: "build", Image: "golang"},
+			Script:    "#!/bin/sh\necho hello\n",
+		}},
+	}
+
+	// Only 1 byte: fewer than the 3 randomSuffix needs for even the first
+	// draw (the place-scripts container name suffix), let alone the
+	// additional draws a scripted step requires.
+	_, _, err := RenderPod(images, taskRun, taskSpec, fakeSecretsLister{}, bytes.NewReader([]byte{1}))
+	if err == nil {
+		t.Fatal("RenderPod() = nil error, want an error for a rand.Reader that runs out of bytes")
+	}
+}
+
+func TestRenderPodPropagatesSecretsListerError(t *testing.T) {
+	images := pipeline.Images{ShellImage: "busybox"}
+	taskRun := &v1alpha1.TaskRun{}
+	taskRun.Name = "my-taskrun"
+	taskRun.Namespace = "my-ns"
+	taskSpec := v1alpha1.TaskSpec{}
+
+	wantErr := context.DeadlineExceeded
+	_, _, err := RenderPod(images, taskRun, taskSpec, fakeSecretsLister{err: wantErr}, bytes.NewReader([]byte{1, 2, 3}))
+	if err != wantErr {
+		t.Errorf("RenderPod() error = %v, want %v", err, wantErr)
+	}
+}